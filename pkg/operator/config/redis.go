@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// _redisCreatedAtKeySuffix marks RedisClient's own bookkeeping keys (recording when EnsureList
+// first saw a key) so ListKeysByPrefix can filter them back out of its results.
+const _redisCreatedAtKeySuffix = ":created_at"
+
+// Redis is the cluster's Redis client, used by batchapi's Redis JobQueue backend when
+// the cluster config's BatchJobQueueBackend is set to clusterconfig.RedisJobQueueBackend. It speaks RESP
+// directly over a short-lived connection per call rather than depending on an external driver, to
+// avoid adding a dependency that clusters not using the Redis backend would never need.
+var Redis = newRedisClient()
+
+// RedisClient is a minimal RESP client exposing only the primitives batchapi's Redis JobQueue
+// backend needs.
+type RedisClient struct {
+	addr string
+}
+
+func newRedisClient() *RedisClient {
+	addr := os.Getenv("CORTEX_REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	return &RedisClient{addr: addr}
+}
+
+func (c *RedisClient) do(args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to connect to %s: %w", c.addr, err)
+	}
+	defer conn.Close()
+
+	if err := writeRESPCommand(conn, args); err != nil {
+		return nil, err
+	}
+
+	return readRESPValue(bufio.NewReader(conn))
+}
+
+// ListKeysByPrefix returns every key in the database starting with prefix, excluding this
+// client's own EnsureList/KeyCreatedAt bookkeeping keys.
+func (c *RedisClient) ListKeysByPrefix(prefix string) ([]string, error) {
+	result, err := c.do("KEYS", prefix+"*")
+	if err != nil {
+		return nil, err
+	}
+
+	rawKeys, err := toStringSlice(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// a bookkeeping key stands in for the key it records, so a key registered with EnsureList is
+	// listed even while its list is empty (and so doesn't exist in Redis)
+	seen := map[string]bool{}
+	keys := make([]string, 0, len(rawKeys))
+	for _, key := range rawKeys {
+		key = strings.TrimSuffix(key, _redisCreatedAtKeySuffix)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// EnsureList makes key discoverable via ListKeysByPrefix and records its creation time, even
+// before anything has been pushed onto it (a Redis list doesn't exist until its first push).
+func (c *RedisClient) EnsureList(key string) error {
+	_, err := c.do("SETNX", key+_redisCreatedAtKeySuffix, strconv.FormatInt(time.Now().Unix(), 10))
+	return err
+}
+
+// DeleteKey deletes key (the list itself) along with its EnsureList bookkeeping.
+func (c *RedisClient) DeleteKey(key string) error {
+	_, err := c.do("DEL", key, key+_redisCreatedAtKeySuffix)
+	return err
+}
+
+// ListLen returns the number of elements in the list at key (0 if the key doesn't exist).
+func (c *RedisClient) ListLen(key string) (int, error) {
+	result, err := c.do("LLEN", key)
+	if err != nil {
+		return 0, err
+	}
+	return toInt(result)
+}
+
+// KeyCreatedAt returns when key was first seen by EnsureList.
+func (c *RedisClient) KeyCreatedAt(key string) (time.Time, error) {
+	result, err := c.do("GET", key+_redisCreatedAtKeySuffix)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	value, ok := result.(string)
+	if !ok || value == "" {
+		return time.Time{}, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("redis: malformed %s value for %s: %w", _redisCreatedAtKeySuffix, key, err)
+	}
+
+	return time.Unix(seconds, 0), nil
+}
+
+// ListRange returns the elements of the list at key between start and stop, inclusive, following
+// Redis' own LRANGE semantics (negative indices count from the end of the list).
+func (c *RedisClient) ListRange(key string, start, stop int) ([]string, error) {
+	result, err := c.do("LRANGE", key, strconv.Itoa(start), strconv.Itoa(stop))
+	if err != nil {
+		return nil, err
+	}
+	return toStringSlice(result)
+}
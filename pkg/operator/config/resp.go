@@ -0,0 +1,137 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// writeRESPCommand writes args as a RESP array of bulk strings, the wire format every Redis
+// command is sent as.
+func writeRESPCommand(w io.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRESPValue parses a single RESP reply: a simple string, error, integer, bulk string, or
+// array of any of those. This covers every reply shape RedisClient's commands can receive.
+func readRESPValue(r *bufio.Reader) (interface{}, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply line")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return strconv.Atoi(line[1:])
+	case '$':
+		return readRESPBulkString(r, line[1:])
+	case '*':
+		return readRESPArray(r, line[1:])
+	default:
+		return nil, fmt.Errorf("redis: unrecognized reply prefix %q", line[0])
+	}
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return line[:len(line)-2], nil // trim trailing \r\n
+}
+
+func readRESPBulkString(r *bufio.Reader, lengthField string) (interface{}, error) {
+	length, err := strconv.Atoi(lengthField)
+	if err != nil {
+		return nil, err
+	}
+	if length == -1 {
+		return nil, nil // nil bulk string: the key or element doesn't exist
+	}
+
+	buf := make([]byte, length+2) // +2 for the trailing \r\n
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return string(buf[:length]), nil
+}
+
+func readRESPArray(r *bufio.Reader, lengthField string) (interface{}, error) {
+	length, err := strconv.Atoi(lengthField)
+	if err != nil {
+		return nil, err
+	}
+	if length == -1 {
+		return nil, nil
+	}
+
+	values := make([]interface{}, length)
+	for i := range values {
+		value, err := readRESPValue(r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func toStringSlice(value interface{}) ([]string, error) {
+	raw, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: expected array reply, got %T", value)
+	}
+
+	result := make([]string, 0, len(raw))
+	for _, item := range raw {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("redis: expected string array element, got %T", item)
+		}
+		result = append(result, str)
+	}
+	return result, nil
+}
+
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("redis: expected integer reply, got %T", value)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"strconv"
+
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+)
+
+const (
+	_attemptStateFileName = "attempt_state.json"
+	_attemptLabel         = "attempt"
+)
+
+// AttemptState is the persisted record of how many of a job's attempts have failed (including a
+// final failure that was not retried) and why each one failed. It lives alongside the rest of the job's status state and is surfaced through
+// the job GET endpoint as JobResponse.Attempt.
+type AttemptState struct {
+	Count          int      `json:"count"`
+	FailureReasons []string `json:"failure_reasons"`
+}
+
+// RecordAttemptFailure increments jobKey's persisted attempt counter, appends reason to its
+// failure history, and returns the new (1-indexed) attempt count for the caller to compare
+// against the job's MaxRetry.
+func RecordAttemptFailure(jobKey spec.JobKey, reason string) (int, error) {
+	attemptState, err := getAttemptState(jobKey)
+	if err != nil {
+		return 0, err
+	}
+
+	attemptState.Count++
+	attemptState.FailureReasons = append(attemptState.FailureReasons, reason)
+
+	if err := setAttemptState(jobKey, attemptState); err != nil {
+		return 0, err
+	}
+
+	return attemptState.Count, nil
+}
+
+// RecreateK8sJobForRetry tears down and recreates jobKey's k8s Job for another attempt, labeled
+// with the new attempt number, reusing the existing queue so already-enqueued-but-unacked messages
+// return to visibility and get redelivered to the new workers.
+func RecreateK8sJobForRetry(jobKey spec.JobKey, attempt int) error {
+	return recreateK8sJobWithAttemptLabel(jobKey, attempt)
+}
+
+// recreateK8sJobWithAttemptLabel deletes jobKey's existing k8s Job and recreates it from a copy of
+// the same spec, labeled with the new attempt number. Deleting the old Job first (rather than
+// creating the new one alongside it) is what returns the already-enqueued-but-unacked messages to
+// queue visibility, since they're only leased for as long as a worker pod holding them is running.
+func recreateK8sJobWithAttemptLabel(jobKey spec.JobKey, attempt int) error {
+	existingJob, err := config.K8s.GetJob(jobKey.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := config.K8s.DeleteJob(jobKey.ID); err != nil {
+		return err
+	}
+
+	retryJob := existingJob.DeepCopy()
+	retryJob.ResourceVersion = ""
+	retryJob.UID = ""
+	if retryJob.Labels == nil {
+		retryJob.Labels = map[string]string{}
+	}
+	retryJob.Labels[_attemptLabel] = strconv.Itoa(attempt)
+
+	_, err = config.K8s.CreateJob(retryJob)
+	return err
+}
+
+func getAttemptState(jobKey spec.JobKey) (AttemptState, error) {
+	var attemptState AttemptState
+	if err := readJobStateFile(jobKey, _attemptStateFileName, &attemptState); err != nil {
+		return AttemptState{}, err
+	}
+	return attemptState, nil
+}
+
+func setAttemptState(jobKey spec.JobKey, attemptState AttemptState) error {
+	return writeJobStateFile(jobKey, _attemptStateFileName, attemptState)
+}
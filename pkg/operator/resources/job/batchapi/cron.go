@@ -25,7 +25,6 @@ import (
 	"github.com/cortexlabs/cortex/pkg/lib/k8s"
 	"github.com/cortexlabs/cortex/pkg/lib/pointer"
 	"github.com/cortexlabs/cortex/pkg/lib/sets/strset"
-	s "github.com/cortexlabs/cortex/pkg/lib/strings"
 	"github.com/cortexlabs/cortex/pkg/lib/telemetry"
 	"github.com/cortexlabs/cortex/pkg/operator/config"
 	"github.com/cortexlabs/cortex/pkg/operator/resources/job"
@@ -42,6 +41,11 @@ const (
 	_doesQueueExistGracePeriod   = 30 * time.Second
 	_enqueuingLivenessBuffer     = 30 * time.Second
 	_k8sJobExistenceGracePeriod  = 10 * time.Second
+
+	// bounds how many finished jobs get examined (not just deleted) per tick so a large backlog of
+	// jobs with no TTL set (which never become eligible for deletion) can't stall the cron
+	_maxFinishedJobsScannedPerTick = 200
+	_maxExpiredJobsPerTick         = 50
 )
 
 var _jobsToDelete = strset.New()
@@ -65,7 +69,7 @@ func ManageJobResources() error {
 		}
 	}
 
-	queues, err := listQueueURLsForAllAPIs()
+	queues, err := _jobQueue.List()
 	if err != nil {
 		return err
 	}
@@ -73,7 +77,7 @@ func ManageJobResources() error {
 	queueURLMap := map[string]string{}
 	queueJobIDSet := strset.Set{}
 	for _, queueURL := range queues {
-		jobKey := jobKeyFromQueueURL(queueURL)
+		jobKey := _jobQueue.KeyFromIdentifier(queueURL)
 		queueJobIDSet.Add(jobKey.ID)
 		queueURLMap[jobKey.ID] = queueURL
 	}
@@ -126,12 +130,52 @@ func ManageJobResources() error {
 			continue
 		}
 
-		newStatusCode, msg, err := reconcileInProgressJob(jobState, queueURL, k8sJob)
+		if _, ok := _inProgressJobSpecMap[jobKey.ID]; !ok {
+			jobSpec, err := downloadJobSpec(jobKey)
+			if err != nil {
+				writeToJobLogStream(jobKey, err.Error(), "terminating job and cleaning up job resources")
+				err := errors.FirstError(
+					job.DeleteInProgressFile(jobKey),
+					deleteJobRuntimeResources(jobKey),
+				)
+				if err != nil {
+					telemetry.Error(err)
+					errors.PrintError(err)
+					continue
+				}
+				continue
+			}
+			_inProgressJobSpecMap[jobKey.ID] = jobSpec
+		}
+
+		jobSpec := _inProgressJobSpecMap[jobKey.ID]
+
+		newStatusCode, msg, err := reconcileInProgressJob(jobSpec, jobState, queueURL, k8sJob)
 		if err != nil {
 			telemetry.Error(err)
 			errors.PrintError(err)
 			continue
 		}
+		if newStatusCode == status.JobUnexpectedError {
+			if err := retryOrTerminate(jobKey, msg, job.SetUnexpectedErrorStatus); err != nil {
+				telemetry.Error(err)
+				errors.PrintError(err)
+			}
+			continue
+		}
+		if newStatusCode == status.JobEnqueueTimedOut {
+			err := errors.FirstError(
+				writeToJobLogStream(jobKey, msg),
+				job.SetStatusForJob(jobKey, newStatusCode),
+				cancelEnqueuer(jobKey),
+				deleteJobRuntimeResources(jobKey),
+			)
+			if err != nil {
+				telemetry.Error(err)
+				errors.PrintError(err)
+			}
+			continue
+		}
 		if newStatusCode != jobState.Status {
 			err = errors.FirstError(
 				writeToJobLogStream(jobKey, msg),
@@ -148,26 +192,6 @@ func ManageJobResources() error {
 			continue
 		}
 
-		if _, ok := _inProgressJobSpecMap[jobKey.ID]; !ok {
-			jobSpec, err := downloadJobSpec(jobKey)
-			if err != nil {
-				writeToJobLogStream(jobKey, err.Error(), "terminating job and cleaning up job resources")
-				err := errors.FirstError(
-					job.DeleteInProgressFile(jobKey),
-					deleteJobRuntimeResources(jobKey),
-				)
-				if err != nil {
-					telemetry.Error(err)
-					errors.PrintError(err)
-					continue
-				}
-				continue
-			}
-			_inProgressJobSpecMap[jobKey.ID] = jobSpec
-		}
-
-		jobSpec := _inProgressJobSpecMap[jobKey.ID]
-
 		if jobSpec.Timeout != nil && time.Since(jobSpec.StartTime) > time.Second*time.Duration(*jobSpec.Timeout) {
 			err := errors.FirstError(
 				job.SetTimedOutStatus(jobKey),
@@ -204,24 +228,18 @@ func ManageJobResources() error {
 
 	// existing queue but no k8sjob and not in progress (existing queue, existing k8sjob and not in progress is handled by the for loop above)
 	for jobID := range strset.Difference(queueJobIDSet, k8sJobIDSet, inProgressJobIDSet) {
-		attributes, err := config.AWS.GetAllQueueAttributes(queueURLMap[jobID])
+		queueCreatedTimestamp, err := _jobQueue.CreatedAt(queueURLMap[jobID])
 		if err != nil {
 			telemetry.Error(err)
 			errors.PrintError(err)
 		}
 
-		queueCreatedTimestamp := time.Time{}
-		parsedSeconds, ok := s.ParseInt64(attributes["CreatedTimestamp"])
-		if ok {
-			queueCreatedTimestamp = time.Unix(parsedSeconds, 0)
-		}
-
 		// queue was created recently, maybe there was a delay between the time queue was created and when the in progress file was written
 		if time.Now().Sub(queueCreatedTimestamp) <= _doesQueueExistGracePeriod {
 			continue
 		}
 
-		jobKey := jobKeyFromQueueURL(queueURLMap[jobID])
+		jobKey := _jobQueue.KeyFromIdentifier(queueURLMap[jobID])
 
 		// delete both k8sjob and queue
 		err = deleteJobRuntimeResources(jobKey)
@@ -238,25 +256,96 @@ func ManageJobResources() error {
 		}
 	}
 
+	if err := gcExpiredFinishedJobs(); err != nil {
+		telemetry.Error(err)
+		errors.PrintError(err)
+	}
+
 	return nil
 }
 
+// deletes the status file and any other per-job state (via job.DeleteJobRecord) for finished jobs
+// whose TTLSecondsAfterFinished has elapsed; the job's log stream is not deleted here. Bounded per
+// tick so a backlog of finished jobs can't stall the cron
+func gcExpiredFinishedJobs() error {
+	finishedJobKeys, err := job.ListAllFinishedJobKeys(userconfig.BatchAPIKind)
+	if err != nil {
+		return err
+	}
+
+	// bound the number of keys examined, not just the number deleted: jobs with no TTL set are
+	// never eligible for deletion and would otherwise be re-downloaded and re-checked every tick forever
+	if len(finishedJobKeys) > _maxFinishedJobsScannedPerTick {
+		finishedJobKeys = finishedJobKeys[:_maxFinishedJobsScannedPerTick]
+	}
+
+	expired := 0
+	for _, jobKey := range finishedJobKeys {
+		if expired >= _maxExpiredJobsPerTick {
+			break
+		}
+
+		jobSpec, err := downloadJobSpec(jobKey)
+		if err != nil {
+			continue
+		}
+
+		jobState, err := job.GetJobState(jobKey)
+		if err != nil {
+			continue
+		}
+
+		finishedAt, ok := jobState.LastUpdatedMap[jobState.Status.String()]
+		if !ok {
+			continue
+		}
+
+		if !isFinishedJobExpired(finishedAt, jobSpec.TTLSecondsAfterFinished, time.Now()) {
+			continue
+		}
+
+		if err := job.DeleteJobRecord(jobKey); err != nil {
+			telemetry.Error(err)
+			errors.PrintError(err)
+			continue
+		}
+
+		expired++
+	}
+
+	return nil
+}
+
+// isFinishedJobExpired reports whether a job that finished at finishedAt is due for garbage
+// collection: TTLSecondsAfterFinished == nil means "keep forever" (never expires), 0 means delete
+// as soon as it's seen.
+func isFinishedJobExpired(finishedAt time.Time, ttlSecondsAfterFinished *int64, now time.Time) bool {
+	if ttlSecondsAfterFinished == nil {
+		return false
+	}
+
+	ttl := time.Second * time.Duration(*ttlSecondsAfterFinished)
+	return now.Sub(finishedAt) >= ttl
+}
+
 // verifies that queue exists for an in progress job and k8s job exists for a job in running status, if verification fails return the a job code to reflect the state
-func reconcileInProgressJob(jobState *job.State, queueURL *string, k8sJob *kbatch.Job) (status.JobCode, string, error) {
+func reconcileInProgressJob(jobSpec *spec.BatchJob, jobState *job.State, queueURL *string, k8sJob *kbatch.Job) (status.JobCode, string, error) {
 	jobKey := jobState.JobKey
 
+	if jobState.Status == status.JobEnqueuing && jobSpec.EnqueueTimeout != nil {
+		enqueueingSince := jobState.LastUpdatedMap[status.JobEnqueuing.String()]
+		if time.Since(enqueueingSince) >= time.Second*time.Duration(*jobSpec.EnqueueTimeout) {
+			return status.JobEnqueueTimedOut, fmt.Sprintf("terminating job %s; exceeded the specified enqueue timeout of %d seconds", jobKey.UserString(), *jobSpec.EnqueueTimeout), nil
+		}
+	}
+
 	if queueURL == nil {
 		if time.Now().Sub(jobState.LastUpdatedMap[status.JobEnqueuing.String()]) <= _doesQueueExistGracePeriod {
 			return jobState.Status, "", nil
 		}
 
-		expectedQueueURL, err := getJobQueueURL(jobKey)
-		if err != nil {
-			return jobState.Status, "", err
-		}
-
 		// unexpected queue missing error
-		return status.JobUnexpectedError, fmt.Sprintf("terminating job %s; sqs queue with url %s was not found", jobKey.UserString(), expectedQueueURL), nil
+		return status.JobUnexpectedError, fmt.Sprintf("terminating job %s; queue %s was not found", jobKey.UserString(), _jobQueue.IdentifierFor(jobKey)), nil
 	}
 
 	if jobState.Status == status.JobEnqueuing && time.Since(jobState.LastUpdatedMap[job.LivenessFile()]) >= _enqueuingLivenessPeriod+_enqueuingLivenessBuffer {
@@ -276,12 +365,33 @@ func reconcileInProgressJob(jobState *job.State, queueURL *string, k8sJob *kbatc
 	return jobState.Status, "", nil
 }
 
+// cancelEnqueuer terminates the enqueuer pod for a job promptly rather than waiting for its
+// liveness file to age out, so an EnqueueTimeout takes effect immediately instead of only bounding
+// how stale the status report can get.
+func cancelEnqueuer(jobKey spec.JobKey) error {
+	pods, err := config.K8s.ListPodsByLabel("jobID", jobKey.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Labels["jobType"] != "enqueuer" {
+			continue
+		}
+		if err := config.K8s.DeletePod(pod.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func checkIfJobCompleted(jobKey spec.JobKey, queueURL string, k8sJob *kbatch.Job) error {
 	if int(k8sJob.Status.Failed) > 0 {
 		return investigateJobFailure(jobKey)
 	}
 
-	queueMessages, err := getQueueMetricsFromURL(queueURL)
+	queueMessages, err := _jobQueue.Metrics(queueURL)
 	if err != nil {
 		return err
 	}
@@ -293,8 +403,7 @@ func checkIfJobCompleted(jobKey spec.JobKey, queueURL string, k8sJob *kbatch.Job
 				_jobsToDelete.Remove(jobKey.ID)
 				return errors.FirstError(
 					writeToJobLogStream(jobKey, "unexpected job status because cluster state indicates job has completed but metrics indicate that job is still in progress"),
-					job.SetUnexpectedErrorStatus(jobKey),
-					deleteJobRuntimeResources(jobKey),
+					finalizeJobStatus(jobKey, job.SetUnexpectedErrorStatus),
 				)
 			}
 			_jobsToDelete.Add(jobKey.ID)
@@ -315,27 +424,21 @@ func checkIfJobCompleted(jobKey spec.JobKey, queueURL string, k8sJob *kbatch.Job
 	if jobSpec.Workers == int(k8sJob.Status.Succeeded) {
 		if jobSpec.TotalBatchCount == batchMetrics.Succeeded {
 			_jobsToDelete.Remove(jobKey.ID)
-			return errors.FirstError(
-				job.SetSucceededStatus(jobKey),
-				deleteJobRuntimeResources(jobKey),
-			)
+			return finalizeJobStatus(jobKey, job.SetSucceededStatus)
 		}
 
 		// wait one more cycle for the success metrics to reach consistency
 		if _jobsToDelete.Has(jobKey.ID) {
 			_jobsToDelete.Remove(jobKey.ID)
-			return errors.FirstError(
-				job.SetCompletedWithFailuresStatus(jobKey),
-				deleteJobRuntimeResources(jobKey),
-			)
+			_ = summarizeDeadLetters(jobKey)
+			return finalizeJobStatus(jobKey, job.SetCompletedWithFailuresStatus)
 		}
 	} else {
 		if _jobsToDelete.Has(jobKey.ID) {
 			_jobsToDelete.Remove(jobKey.ID)
 			return errors.FirstError(
 				writeToJobLogStream(jobKey, "unexpected job state; queue is empty but cluster state still indicates that the job is still in progress"),
-				job.SetUnexpectedErrorStatus(jobKey),
-				deleteJobRuntimeResources(jobKey),
+				finalizeJobStatus(jobKey, job.SetUnexpectedErrorStatus),
 			)
 		}
 	}
@@ -351,14 +454,12 @@ func checkIfJobCompleted(jobKey spec.JobKey, queueURL string, k8sJob *kbatch.Job
 func investigateJobFailure(jobKey spec.JobKey) error {
 	reasonFound := false
 
+	_ = summarizeDeadLetters(jobKey)
+
 	pods, _ := config.K8s.ListPodsByLabel("jobID", jobKey.ID)
 	for _, pod := range pods {
 		if k8s.WasPodOOMKilled(&pod) {
-			return errors.FirstError(
-				writeToJobLogStream(jobKey, "at least one worker was killed because it ran out of out of memory"),
-				job.SetWorkerOOMStatus(jobKey),
-				deleteJobRuntimeResources(jobKey),
-			)
+			return retryOrTerminate(jobKey, "at least one worker was killed because it ran out of out of memory", job.SetWorkerOOMStatus)
 		}
 		podStatus := k8s.GetPodStatus(&pod)
 		for _, containerStatus := range pod.Status.ContainerStatuses {
@@ -376,14 +477,46 @@ func investigateJobFailure(jobKey spec.JobKey) error {
 		}
 	}
 
-	var err error
-	if !reasonFound {
-		err = writeToJobLogStream(jobKey, "workers were killed for unknown reason")
+	reason := "workers were killed for unknown reason"
+	if reasonFound {
+		reason = "job failed; see above for worker termination reasons"
+	}
+
+	return retryOrTerminate(jobKey, reason, job.SetWorkerErrorStatus)
+}
+
+// retryOrTerminate persists the failure reason against the job's attempt count and, if MaxRetry
+// has not yet been exhausted, recreates the k8s Job for another attempt (reusing the same queue)
+// instead of tearing the job down. Once retries are exhausted it falls through to terminalStatus.
+func retryOrTerminate(jobKey spec.JobKey, reason string, terminalStatus func(spec.JobKey) error) error {
+	jobSpec, err := downloadJobSpec(jobKey)
+	if err != nil {
+		return err
+	}
+
+	attempt, err := job.RecordAttemptFailure(jobKey, reason)
+	if err != nil {
+		return err
+	}
+
+	if shouldRetry(attempt, jobSpec.MaxRetry) {
+		return errors.FirstError(
+			writeToJobLogStream(jobKey, fmt.Sprintf("%s; retrying (attempt %d of %d)", reason, attempt, *jobSpec.MaxRetry)),
+			job.RecreateK8sJobForRetry(jobKey, attempt),
+		)
 	}
 
 	return errors.FirstError(
-		err,
-		job.SetWorkerErrorStatus(jobKey),
-		deleteJobRuntimeResources(jobKey),
+		writeToJobLogStream(jobKey, reason),
+		finalizeJobStatus(jobKey, terminalStatus),
 	)
 }
+
+// shouldRetry reports whether a job that just failed its attempt'th attempt should be retried:
+// maxRetry == nil means retries are disabled, so the job always goes terminal on first failure.
+func shouldRetry(attempt int, maxRetry *int) bool {
+	if maxRetry == nil {
+		return false
+	}
+	return attempt <= *maxRetry
+}
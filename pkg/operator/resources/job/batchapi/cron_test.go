@@ -0,0 +1,105 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsFinishedJobExpired(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		finishedAt time.Time
+		ttl        *int64
+		want       bool
+	}{
+		{
+			name:       "nil ttl means keep forever",
+			finishedAt: now.Add(-time.Hour),
+			ttl:        nil,
+			want:       false,
+		},
+		{
+			name:       "zero ttl expires immediately",
+			finishedAt: now,
+			ttl:        int64Ptr(0),
+			want:       true,
+		},
+		{
+			name:       "not yet past ttl",
+			finishedAt: now.Add(-30 * time.Second),
+			ttl:        int64Ptr(60),
+			want:       false,
+		},
+		{
+			name:       "exactly at ttl boundary is expired",
+			finishedAt: now.Add(-60 * time.Second),
+			ttl:        int64Ptr(60),
+			want:       true,
+		},
+		{
+			name:       "past ttl",
+			finishedAt: now.Add(-61 * time.Second),
+			ttl:        int64Ptr(60),
+			want:       true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := isFinishedJobExpired(tc.finishedAt, tc.ttl, now)
+			if got != tc.want {
+				t.Errorf("isFinishedJobExpired() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		name     string
+		attempt  int
+		maxRetry *int
+		want     bool
+	}{
+		{name: "nil max retry disables retries", attempt: 1, maxRetry: nil, want: false},
+		{name: "first failure within budget", attempt: 1, maxRetry: intPtr(2), want: true},
+		{name: "last allowed attempt", attempt: 2, maxRetry: intPtr(2), want: true},
+		{name: "exhausted budget", attempt: 3, maxRetry: intPtr(2), want: false},
+		{name: "zero max retry never retries", attempt: 1, maxRetry: intPtr(0), want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := shouldRetry(tc.attempt, tc.maxRetry)
+			if got != tc.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cortexlabs/cortex/pkg/lib/errors"
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+)
+
+const _maxDeadLetterSummaryMessages = 10
+
+// dlqQueueName derives jobKey's dead-letter queue name deterministically from the job key, so
+// getJobDeadLetterQueueURL can recover it without needing to persist it anywhere.
+func dlqQueueName(jobKey spec.JobKey) string {
+	return "batch-" + jobKey.APIName + "-" + jobKey.ID + "-dlq"
+}
+
+// getJobDeadLetterQueueURL returns the URL of jobKey's dead-letter queue, provisioning it first if
+// it doesn't already exist (SQS's CreateQueue is idempotent, so this is safe to call on every read).
+func getJobDeadLetterQueueURL(jobKey spec.JobKey) (string, error) {
+	return config.AWS.CreateQueue(dlqQueueName(jobKey), nil)
+}
+
+// provisionDeadLetterQueue creates jobKey's dead-letter queue and points queueURL's redrive policy
+// at it, so a batch gets moved off the main queue after maxReceiveCount failed receives.
+func provisionDeadLetterQueue(jobKey spec.JobKey, queueURL string, maxReceiveCount int) error {
+	dlqURL, err := getJobDeadLetterQueueURL(jobKey)
+	if err != nil {
+		return err
+	}
+
+	dlqAttributes, err := config.AWS.GetAllQueueAttributes(dlqURL)
+	if err != nil {
+		return errors.WrapPrefix(err, "failed to get queue attributes for "+dlqURL)
+	}
+
+	redrivePolicy := fmt.Sprintf(`{"deadLetterTargetArn":"%s","maxReceiveCount":"%d"}`, dlqAttributes["QueueArn"], maxReceiveCount)
+
+	return config.AWS.SetQueueAttributes(queueURL, map[string]string{"RedrivePolicy": redrivePolicy})
+}
+
+// FailedBatches returns up to _maxDeadLetterSummaryMessages of the raw payloads that were routed
+// to jobKey's dead-letter queue because they exceeded DeadLetterQueue.MaxReceiveCount on the main
+// queue. Backs the
+// GET /batch/{apiName}/{jobID}/failed-batches endpoint.
+func FailedBatches(jobKey spec.JobKey) ([]string, error) {
+	jobSpec, err := downloadJobSpec(jobKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobSpec.DeadLetterQueue == nil {
+		return nil, errors.ErrorUnexpected(fmt.Sprintf("job %s does not have a dead-letter queue configured", jobKey.UserString()))
+	}
+
+	return _jobQueue.ReadDeadLetters(jobKey, _maxDeadLetterSummaryMessages)
+}
+
+// failedBatchesResponse is the JSON payload returned by GetFailedBatchesHandler.
+type failedBatchesResponse struct {
+	FailedBatches []string `json:"failed_batches"`
+}
+
+// GetFailedBatchesHandler serves GET /batch/{apiName}/{jobID}/failed-batches, returning up to
+// _maxDeadLetterSummaryMessages of the raw payloads that landed in the job's dead-letter queue.
+func GetFailedBatchesHandler(w http.ResponseWriter, r *http.Request) {
+	jobKey, err := jobKeyFromPath(r.URL.Path, 3)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	failedBatches, err := FailedBatches(jobKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(failedBatchesResponse{FailedBatches: failedBatches})
+}
+
+// summarizeDeadLetters writes a short summary of jobKey's dead-letter queue contents to its log
+// stream, giving users the same "which specific inputs failed" observability that a boolean
+// success/fail per worker does not provide.
+func summarizeDeadLetters(jobKey spec.JobKey) error {
+	jobSpec, err := downloadJobSpec(jobKey)
+	if err != nil {
+		return err
+	}
+
+	if jobSpec.DeadLetterQueue == nil {
+		return nil
+	}
+
+	failedBatches, err := _jobQueue.ReadDeadLetters(jobKey, _maxDeadLetterSummaryMessages)
+	if err != nil {
+		return err
+	}
+
+	if len(failedBatches) == 0 {
+		return nil
+	}
+
+	return writeToJobLogStream(jobKey, fmt.Sprintf(
+		"%d batch(es) failed and were moved to the dead-letter queue; see GET /batch/%s/%s/failed-batches for the failed inputs",
+		len(failedBatches), jobKey.APIName, jobKey.ID,
+	))
+}
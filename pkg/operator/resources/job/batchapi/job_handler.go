@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cortexlabs/cortex/pkg/lib/errors"
+	"github.com/cortexlabs/cortex/pkg/operator/resources/job"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+)
+
+// GetJobHandler serves GET /batch/{apiName}/{jobID}, returning the job's spec plus its retry
+// attempt history (count and failure reasons, if at least one attempt has failed).
+func GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobKey, err := jobKeyFromPath(r.URL.Path, 2)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobSpec, err := downloadJobSpec(jobKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := job.GetJobResponse(jobKey, *jobSpec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// jobKeyFromPath extracts {apiName}/{jobID} from a /batch/{apiName}/{jobID}[/...] request path,
+// where wantParts is the number of path segments expected after "batch" (2 for the bare job path,
+// 3 for a path with one more trailing segment like "failed-batches").
+func jobKeyFromPath(path string, wantParts int) (spec.JobKey, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != wantParts+1 || parts[0] != "batch" {
+		return spec.JobKey{}, errors.ErrorUnexpected(fmt.Sprintf("malformed batch job request path %q", path))
+	}
+	return spec.JobKey{APIName: parts[1], ID: parts[2]}, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"github.com/cortexlabs/cortex/pkg/lib/errors"
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+	"github.com/cortexlabs/cortex/pkg/types/userconfig"
+)
+
+// deleteJobRuntimeResources tears down the queue and k8s Job backing jobKey, honoring the job's
+// DeletionPolicy. The queue is always deleted immediately, regardless of policy -- a job that's
+// being torn down should never accept more work. What differs is the k8s Job, per
+// deleteK8sJobForPolicy.
+func deleteJobRuntimeResources(jobKey spec.JobKey) error {
+	if err := _jobQueue.Delete(_jobQueue.IdentifierFor(jobKey)); err != nil {
+		return err
+	}
+	return deleteK8sJobForPolicy(jobKey)
+}
+
+// deleteK8sJobForPolicy deletes jobKey's k8s Job according to its DeletionPolicy (the queue is
+// handled separately by deleteJobRuntimeResources/finalizeJobStatus):
+//   - Background (the default): delete it immediately.
+//   - Foreground: only once its pods are confirmed gone; if pods are still present this tick,
+//     it's a no-op and the next tick retries.
+//   - Orphan: leave it in place for post-mortem debugging.
+func deleteK8sJobForPolicy(jobKey spec.JobKey) error {
+	switch deletionPolicyForJob(jobKey) {
+	case userconfig.OrphanDeletionPolicy:
+		return nil
+	case userconfig.ForegroundDeletionPolicy:
+		return deleteK8sJobForeground(jobKey)
+	default:
+		return config.K8s.DeleteJob(jobKey.ID)
+	}
+}
+
+// deleteK8sJobForeground deletes jobKey's k8s Job only once no pods remain for it; if pods are
+// still running it's a no-op, and the next tick's call into deleteK8sJobForPolicy retries it.
+func deleteK8sJobForeground(jobKey spec.JobKey) error {
+	pods, err := config.K8s.ListPodsByLabel("jobID", jobKey.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(pods) > 0 {
+		return nil
+	}
+
+	return config.K8s.DeleteJob(jobKey.ID)
+}
+
+func deletionPolicyForJob(jobKey spec.JobKey) userconfig.DeletionPolicy {
+	jobSpec, err := downloadJobSpec(jobKey)
+	if err != nil || jobSpec.DeletionPolicy == "" {
+		return userconfig.BackgroundDeletionPolicy
+	}
+	return jobSpec.DeletionPolicy
+}
+
+// finalizeJobStatus deletes jobKey's queue, then transitions it to its terminal status and cleans
+// up its k8s Job. For a Foreground DeletionPolicy it first verifies no worker pods remain; if any
+// are still running it defers the status transition and the k8s Job deletion to a later tick (but
+// still deletes the queue now), so downstream systems polling the API never observe a terminal
+// status while workers are still writing final logs.
+func finalizeJobStatus(jobKey spec.JobKey, setStatus func(spec.JobKey) error) error {
+	if err := _jobQueue.Delete(_jobQueue.IdentifierFor(jobKey)); err != nil {
+		return err
+	}
+
+	if deletionPolicyForJob(jobKey) == userconfig.ForegroundDeletionPolicy {
+		pods, err := config.K8s.ListPodsByLabel("jobID", jobKey.ID)
+		if err != nil {
+			return err
+		}
+		if len(pods) > 0 {
+			return nil
+		}
+	}
+
+	return errors.FirstError(
+		setStatus(jobKey),
+		deleteK8sJobForPolicy(jobKey),
+	)
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cortexlabs/cortex/pkg/lib/errors"
+	s "github.com/cortexlabs/cortex/pkg/lib/strings"
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/clusterconfig"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+)
+
+// QueueMetrics is the backend-agnostic view of a job queue's depth that ManageJobResources reasons about.
+type QueueMetrics struct {
+	Visible    int
+	NotVisible int
+}
+
+func (m QueueMetrics) IsEmpty() bool {
+	return m.Visible == 0 && m.NotVisible == 0
+}
+
+// JobQueue is the seam between batchapi's reconciliation logic and the messaging system that holds
+// a job's work items. ManageJobResources operates purely against this interface so that clusters
+// which don't want to depend on AWS SQS can configure an alternate backend (e.g. Redis).
+type JobQueue interface {
+	// List returns the identifier of every queue currently provisioned for this cluster's batch APIs.
+	List() ([]string, error)
+	// Create provisions a new queue for jobSpec and returns its identifier. In the SQS backend, if
+	// jobSpec.DeadLetterQueue is set, it also provisions the paired dead-letter queue and points the
+	// main queue's redrive policy at it; the Redis backend does not act on jobSpec.DeadLetterQueue.
+	Create(jobSpec spec.BatchJob) (string, error)
+	// Delete tears down the queue referenced by identifier.
+	Delete(identifier string) error
+	// Metrics reports the current visible/not-visible message counts for the queue.
+	Metrics(identifier string) (QueueMetrics, error)
+	// CreatedAt reports when the queue referenced by identifier was provisioned.
+	CreatedAt(identifier string) (time.Time, error)
+	// KeyFromIdentifier recovers the job key that a queue identifier was created for.
+	KeyFromIdentifier(identifier string) spec.JobKey
+	// IdentifierFor returns the identifier that Create would have returned for jobKey.
+	IdentifierFor(jobKey spec.JobKey) string
+	// ReadDeadLetters returns up to limit raw payloads that were routed to jobKey's dead-letter
+	// queue (messages that exceeded DeadLetterQueue.MaxReceiveCount on the main queue).
+	ReadDeadLetters(jobKey spec.JobKey, limit int) ([]string, error)
+}
+
+// newJobQueue selects the JobQueue backend configured for this cluster; SQS is the default used by
+// every cluster provisioned before backend selection existed.
+func newJobQueue() JobQueue {
+	if config.Cluster != nil && config.Cluster.BatchJobQueueBackend == clusterconfig.RedisJobQueueBackend {
+		return newRedisJobQueue()
+	}
+	return newSQSJobQueue()
+}
+
+var _jobQueue = newJobQueue()
+
+type sqsJobQueue struct{}
+
+func newSQSJobQueue() *sqsJobQueue {
+	return &sqsJobQueue{}
+}
+
+func (q *sqsJobQueue) List() ([]string, error) {
+	return listQueueURLsForAllAPIs()
+}
+
+func (q *sqsJobQueue) Create(jobSpec spec.BatchJob) (string, error) {
+	queueURL, err := createQueueForJob(jobSpec.JobKey)
+	if err != nil {
+		return "", err
+	}
+
+	if jobSpec.DeadLetterQueue == nil {
+		return queueURL, nil
+	}
+
+	if err := provisionDeadLetterQueue(jobSpec.JobKey, queueURL, jobSpec.DeadLetterQueue.MaxReceiveCount); err != nil {
+		return "", err
+	}
+
+	return queueURL, nil
+}
+
+func (q *sqsJobQueue) Delete(identifier string) error {
+	return config.AWS.DeleteQueue(identifier)
+}
+
+func (q *sqsJobQueue) Metrics(identifier string) (QueueMetrics, error) {
+	return getQueueMetricsFromURL(identifier)
+}
+
+func (q *sqsJobQueue) CreatedAt(identifier string) (time.Time, error) {
+	attributes, err := config.AWS.GetAllQueueAttributes(identifier)
+	if err != nil {
+		return time.Time{}, errors.WrapPrefix(err, "failed to get queue attributes for "+identifier)
+	}
+
+	parsedSeconds, ok := s.ParseInt64(attributes["CreatedTimestamp"])
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(parsedSeconds, 0), nil
+}
+
+func (q *sqsJobQueue) KeyFromIdentifier(identifier string) spec.JobKey {
+	return jobKeyFromQueueURL(identifier)
+}
+
+func (q *sqsJobQueue) IdentifierFor(jobKey spec.JobKey) string {
+	return getJobQueueURL(jobKey)
+}
+
+func (q *sqsJobQueue) ReadDeadLetters(jobKey spec.JobKey, limit int) ([]string, error) {
+	dlqURL, err := getJobDeadLetterQueueURL(jobKey)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := config.AWS.ReceiveMessages(dlqURL, limit)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "failed to read dead-letter queue for "+jobKey.UserString())
+	}
+
+	return messages, nil
+}
+
+// redisJobQueue implements JobQueue on top of a Redis list per job, alongside an ":inprogress"
+// list whose length is reported as not-visible messages and a ":dlq" list read as dead letters.
+// It only registers, measures, reads and deletes these lists; nothing in this type pushes, leases
+// or expires messages.
+type redisJobQueue struct{}
+
+func newRedisJobQueue() *redisJobQueue {
+	return &redisJobQueue{}
+}
+
+func (q *redisJobQueue) List() ([]string, error) {
+	keys, err := config.Redis.ListKeysByPrefix(_redisQueueKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// the in-progress and dead-letter lists share the queue prefix but are not queues themselves
+	queues := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if strings.HasSuffix(key, _redisInProgressSuffix) || strings.HasSuffix(key, _redisDeadLetterSuffix) {
+			continue
+		}
+		queues = append(queues, key)
+	}
+	return queues, nil
+}
+
+// Create registers only the main list; it sets up no dead-letter routing for it, even when
+// jobSpec.DeadLetterQueue is set.
+func (q *redisJobQueue) Create(jobSpec spec.BatchJob) (string, error) {
+	key := q.IdentifierFor(jobSpec.JobKey)
+	if err := config.Redis.EnsureList(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (q *redisJobQueue) Delete(identifier string) error {
+	return config.Redis.DeleteKey(identifier)
+}
+
+func (q *redisJobQueue) Metrics(identifier string) (QueueMetrics, error) {
+	visible, err := config.Redis.ListLen(identifier)
+	if err != nil {
+		return QueueMetrics{}, err
+	}
+	notVisible, err := config.Redis.ListLen(identifier + _redisInProgressSuffix)
+	if err != nil {
+		return QueueMetrics{}, err
+	}
+	return QueueMetrics{Visible: visible, NotVisible: notVisible}, nil
+}
+
+func (q *redisJobQueue) CreatedAt(identifier string) (time.Time, error) {
+	return config.Redis.KeyCreatedAt(identifier)
+}
+
+func (q *redisJobQueue) KeyFromIdentifier(identifier string) spec.JobKey {
+	apiName, jobID := splitRedisQueueKey(identifier)
+	return spec.JobKey{APIName: apiName, ID: jobID}
+}
+
+func (q *redisJobQueue) IdentifierFor(jobKey spec.JobKey) string {
+	return _redisQueueKeyPrefix + jobKey.APIName + ":" + jobKey.ID
+}
+
+func (q *redisJobQueue) ReadDeadLetters(jobKey spec.JobKey, limit int) ([]string, error) {
+	return config.Redis.ListRange(q.IdentifierFor(jobKey)+_redisDeadLetterSuffix, 0, limit-1)
+}
+
+const (
+	_redisQueueKeyPrefix   = "batchqueue:"
+	_redisInProgressSuffix = ":inprogress"
+	_redisDeadLetterSuffix = ":dlq"
+)
+
+func splitRedisQueueKey(key string) (apiName string, jobID string) {
+	if !strings.HasPrefix(key, _redisQueueKeyPrefix) {
+		return "", ""
+	}
+
+	trimmed := strings.TrimPrefix(key, _redisQueueKeyPrefix)
+	parts := strings.SplitN(trimmed, ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import "testing"
+
+func TestSplitRedisQueueKey(t *testing.T) {
+	cases := []struct {
+		name        string
+		key         string
+		wantAPIName string
+		wantJobID   string
+	}{
+		{
+			name:        "well formed key",
+			key:         _redisQueueKeyPrefix + "my-api:abc123",
+			wantAPIName: "my-api",
+			wantJobID:   "abc123",
+		},
+		{
+			name:        "job id containing colons",
+			key:         _redisQueueKeyPrefix + "my-api:abc:123",
+			wantAPIName: "my-api",
+			wantJobID:   "abc:123",
+		},
+		{
+			name:        "missing prefix is treated as malformed",
+			key:         "my-api:abc123",
+			wantAPIName: "",
+			wantJobID:   "",
+		},
+		{
+			name:        "no separator is malformed",
+			key:         _redisQueueKeyPrefix + "my-api",
+			wantAPIName: "",
+			wantJobID:   "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiName, jobID := splitRedisQueueKey(tc.key)
+			if apiName != tc.wantAPIName || jobID != tc.wantJobID {
+				t.Errorf("splitRedisQueueKey(%q) = (%q, %q), want (%q, %q)", tc.key, apiName, jobID, tc.wantAPIName, tc.wantJobID)
+			}
+		})
+	}
+}
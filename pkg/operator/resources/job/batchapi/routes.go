@@ -0,0 +1,40 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RegisterRoutes mounts batchapi's job endpoints on mux:
+//   - GET /batch/{apiName}/{jobID}
+//   - GET /batch/{apiName}/{jobID}/failed-batches
+//
+// The operator's main router is expected to call this during startup alongside the routes for the
+// other resource kinds (RealtimeAPI, TaskAPI).
+func RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/batch/", routeJobRequest)
+}
+
+func routeJobRequest(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/failed-batches") {
+		GetFailedBatchesHandler(w, r)
+		return
+	}
+	GetJobHandler(w, r)
+}
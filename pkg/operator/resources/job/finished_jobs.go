@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"strings"
+
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+	"github.com/cortexlabs/cortex/pkg/types/userconfig"
+)
+
+// ListAllFinishedJobKeys returns the key of every job of the given kind that has reached a
+// terminal status, for gcExpiredFinishedJobs to consider for TTLSecondsAfterFinished cleanup.
+func ListAllFinishedJobKeys(kind userconfig.Kind) ([]spec.JobKey, error) {
+	jobKeys, err := listAllJobKeys(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	finishedJobKeys := make([]spec.JobKey, 0, len(jobKeys))
+	for _, jobKey := range jobKeys {
+		jobState, err := GetJobState(jobKey)
+		if err != nil {
+			continue
+		}
+		if !jobState.Status.IsInProgress() {
+			finishedJobKeys = append(finishedJobKeys, jobKey)
+		}
+	}
+
+	return finishedJobKeys, nil
+}
+
+// DeleteJobRecord deletes jobKey's status file and any other per-job state (e.g. attempt history)
+// recorded under it. Log stream retention is governed separately by the cluster's log-group
+// retention policy, not by this function.
+func DeleteJobRecord(jobKey spec.JobKey) error {
+	return config.AWS.DeleteS3Prefix(jobStateDir(jobKey))
+}
+
+// listAllJobKeys lists every job key of the given kind recorded in the job state store.
+func listAllJobKeys(kind userconfig.Kind) ([]spec.JobKey, error) {
+	dirs, err := config.AWS.ListS3Prefix(jobStateKindPrefix(kind))
+	if err != nil {
+		return nil, err
+	}
+
+	jobKeys := make([]spec.JobKey, 0, len(dirs))
+	for _, dir := range dirs {
+		apiName, jobID, ok := splitJobStateDir(kind, dir)
+		if !ok {
+			continue
+		}
+		jobKeys = append(jobKeys, spec.JobKey{APIName: apiName, ID: jobID})
+	}
+
+	return jobKeys, nil
+}
+
+// splitJobStateDir recovers the apiName/jobID that jobStateDir encoded into dir, a key returned
+// by listing jobStateKindPrefix(kind).
+func splitJobStateDir(kind userconfig.Kind, dir string) (apiName string, jobID string, ok bool) {
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(dir, jobStateKindPrefix(kind)), "/")
+	parts := strings.SplitN(trimmed, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import "github.com/cortexlabs/cortex/pkg/types/spec"
+
+// JobResponse is the payload returned by the job GET endpoint (GET /batch/{apiName}/{jobID}).
+type JobResponse struct {
+	spec.BatchJob `json:",inline"`
+
+	// Attempt is nil for jobs that have never failed an attempt; after the first failure (whether
+	// or not it was retried) it reports how many attempts have failed so far and why each one failed.
+	Attempt *AttemptState `json:"attempt,omitempty"`
+}
+
+// GetJobResponse assembles the GET endpoint payload for jobKey, including its retry history.
+func GetJobResponse(jobKey spec.JobKey, jobSpec spec.BatchJob) (JobResponse, error) {
+	attemptState, err := getAttemptState(jobKey)
+	if err != nil {
+		return JobResponse{}, err
+	}
+
+	response := JobResponse{BatchJob: jobSpec}
+	if attemptState.Count > 0 {
+		response.Attempt = &attemptState
+	}
+
+	return response, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	"github.com/cortexlabs/cortex/pkg/operator/config"
+	"github.com/cortexlabs/cortex/pkg/types/spec"
+	"github.com/cortexlabs/cortex/pkg/types/userconfig"
+)
+
+// jobStateKindPrefix is the S3 prefix under which every job of kind has its per-job state
+// directory.
+func jobStateKindPrefix(kind userconfig.Kind) string {
+	return fmt.Sprintf("job_state/%s/", kind.String())
+}
+
+// jobStateDir is the per-job directory holding jobKey's status file and any other persisted
+// state (e.g. attempt history). Retry/attempt tracking is currently BatchAPI-only, so this always
+// resolves under the BatchAPI kind prefix.
+func jobStateDir(jobKey spec.JobKey) string {
+	return fmt.Sprintf("%s%s/%s/", jobStateKindPrefix(userconfig.BatchAPIKind), jobKey.APIName, jobKey.ID)
+}
+
+// jobStateFileKey is the S3 key for fileName within jobKey's state directory.
+func jobStateFileKey(jobKey spec.JobKey, fileName string) string {
+	return jobStateDir(jobKey) + fileName
+}
+
+// readJobStateFile unmarshals jobKey's fileName into out. A missing file (e.g. a job that has
+// never failed an attempt has no attempt state file yet) leaves out at its zero value and returns
+// no error.
+func readJobStateFile(jobKey spec.JobKey, fileName string, out interface{}) error {
+	_, err := config.AWS.ReadJSONFromS3(jobStateFileKey(jobKey, fileName), out)
+	return err
+}
+
+// writeJobStateFile marshals value and writes it to jobKey's fileName.
+func writeJobStateFile(jobKey spec.JobKey, fileName string, value interface{}) error {
+	return config.AWS.UploadJSONToS3(jobStateFileKey(jobKey, fileName), value)
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterconfig
+
+// JobQueueBackend selects which messaging system batchapi uses to hold a job's work items.
+type JobQueueBackend string
+
+const (
+	// SQSJobQueueBackend is the default, used by every cluster provisioned before backend
+	// selection existed.
+	SQSJobQueueBackend JobQueueBackend = "sqs"
+	// RedisJobQueueBackend lets on-prem clusters avoid depending on AWS SQS.
+	RedisJobQueueBackend JobQueueBackend = "redis"
+)
+
+// Config is the cluster-wide configuration read from the cluster config YAML.
+type Config struct {
+	// BatchJobQueueBackend selects the JobQueue implementation batchapi uses. Defaults to
+	// SQSJobQueueBackend when unset.
+	BatchJobQueueBackend JobQueueBackend `json:"batch_job_queue_backend" yaml:"batch_job_queue_backend"`
+}
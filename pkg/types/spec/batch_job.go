@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"time"
+
+	"github.com/cortexlabs/cortex/pkg/types/userconfig"
+)
+
+// BatchJob is the runtime record of a submitted BatchAPI job: the user-provided submission fields
+// plus the bookkeeping the operator fills in as the job progresses.
+type BatchJob struct {
+	JobKey `json:",inline"`
+
+	Workers         int       `json:"workers" yaml:"workers"`
+	TotalBatchCount int       `json:"total_batch_count"`
+	StartTime       time.Time `json:"start_time"`
+
+	// Timeout is the number of seconds after StartTime before the job is terminated for running too long.
+	Timeout *int64 `json:"timeout" yaml:"timeout"`
+
+	// TTLSecondsAfterFinished is the number of seconds after a job reaches a terminal status
+	// before its record (status file and other per-job state) is garbage collected; its log stream
+	// is not deleted by this garbage collection.
+	// Modeled after Kubernetes' ttlSecondsAfterFinished: nil means keep the record forever, 0
+	// means delete it as soon as it's observed to have finished.
+	TTLSecondsAfterFinished *int64 `json:"ttl_seconds_after_finished" yaml:"ttl_seconds_after_finished"`
+
+	// MaxRetry is how many additional attempts a job gets after a worker failure, OOM, or
+	// unexpected error before it is marked as terminally failed. nil (or 0) means no retries.
+	MaxRetry *int `json:"max_retry" yaml:"max_retry"`
+
+	// EnqueueTimeout bounds how long a job may spend in the JobEnqueuing status before it is
+	// terminated with JobEnqueueTimedOut, independent of the enqueuer's liveness check. nil means
+	// enqueuing is only bounded by the liveness check.
+	EnqueueTimeout *int64 `json:"enqueue_timeout" yaml:"enqueue_timeout"`
+
+	// DeletionPolicy controls what happens to this job's k8s Job and queue once it reaches a
+	// terminal status. Empty defaults to userconfig.BackgroundDeletionPolicy.
+	DeletionPolicy userconfig.DeletionPolicy `json:"deletion_policy" yaml:"deletion_policy"`
+
+	// DeadLetterQueue configures a secondary queue that receives batches which failed to process
+	// too many times. nil disables dead-letter handling entirely: failed batches are simply dropped
+	// once MaxRetry on the job itself is exhausted.
+	DeadLetterQueue *DeadLetterQueue `json:"dead_letter_queue" yaml:"dead_letter_queue"`
+}
+
+// DeadLetterQueue configures the dead-letter queue for a BatchAPI job.
+type DeadLetterQueue struct {
+	// MaxReceiveCount is how many times a batch may be received from the main queue before it is
+	// moved to the dead-letter queue instead of being redelivered.
+	MaxReceiveCount int `json:"max_receive_count" yaml:"max_receive_count"`
+
+	// Retain is accepted but not read by the operator: tearing down a job's runtime resources
+	// deletes only its main queue, so the dead-letter queue is left in place whether or not Retain
+	// is set.
+	Retain bool `json:"retain" yaml:"retain"`
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"github.com/cortexlabs/cortex/pkg/lib/errors"
+	"github.com/cortexlabs/cortex/pkg/types/userconfig"
+)
+
+// Validate checks every BatchAPI-specific submission field on j. The job submission path calls
+// this (alongside the rest of its API spec validation) before a job is accepted.
+func (j *BatchJob) Validate() error {
+	validationErrs := []error{
+		userconfig.ValidateTTLSecondsAfterFinished(j.TTLSecondsAfterFinished),
+		userconfig.ValidateMaxRetry(j.MaxRetry),
+		userconfig.ValidateEnqueueTimeout(j.EnqueueTimeout),
+		userconfig.ValidateDeletionPolicy(j.DeletionPolicy),
+	}
+
+	if j.DeadLetterQueue != nil {
+		validationErrs = append(validationErrs, userconfig.ValidateMaxReceiveCount(j.DeadLetterQueue.MaxReceiveCount))
+	}
+
+	return errors.FirstError(validationErrs...)
+}
@@ -0,0 +1,30 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import "fmt"
+
+// JobKey identifies a single job run of a single API.
+type JobKey struct {
+	APIName string `json:"api_name"`
+	ID      string `json:"id"`
+}
+
+// UserString is the human-readable identifier shown in logs and error messages.
+func (jobKey JobKey) UserString() string {
+	return fmt.Sprintf("%s (API %s)", jobKey.ID, jobKey.APIName)
+}
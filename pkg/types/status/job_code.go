@@ -0,0 +1,63 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+// JobCode is the status of a job at a point in time.
+type JobCode int
+
+const (
+	JobEnqueuing JobCode = iota
+	JobRunning
+	JobEnqueueFailed
+	JobUnexpectedError
+	JobSucceeded
+	JobCompletedWithFailures
+	JobTimedOut
+	JobWorkerError
+	JobWorkerOOM
+
+	// JobEnqueueTimedOut indicates that a job exceeded its EnqueueTimeout while still in the
+	// JobEnqueuing phase (as opposed to JobEnqueueFailed, which indicates the enqueuer's liveness
+	// check lapsed).
+	JobEnqueueTimedOut
+)
+
+var _jobCodeStrings = map[JobCode]string{
+	JobEnqueuing:             "enqueuing",
+	JobRunning:               "running",
+	JobEnqueueFailed:         "enqueue_failed",
+	JobUnexpectedError:       "unexpected_error",
+	JobSucceeded:             "succeeded",
+	JobCompletedWithFailures: "completed_with_failures",
+	JobTimedOut:              "timed_out",
+	JobWorkerError:           "worker_error",
+	JobWorkerOOM:             "worker_oom",
+	JobEnqueueTimedOut:       "enqueue_timed_out",
+}
+
+func (c JobCode) String() string {
+	if s, ok := _jobCodeStrings[c]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// IsInProgress reports whether a job in this status is still being worked on (as opposed to
+// having reached a terminal status).
+func (c JobCode) IsInProgress() bool {
+	return c == JobEnqueuing || c == JobRunning
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userconfig
+
+import "fmt"
+
+// ValidateTTLSecondsAfterFinished validates the optional ttl_seconds_after_finished field of a
+// BatchAPI job submission. nil (unset) means "keep the job record forever" and is always valid;
+// a negative value makes no sense as a duration.
+func ValidateTTLSecondsAfterFinished(ttl *int64) error {
+	if ttl == nil {
+		return nil
+	}
+	if *ttl < 0 {
+		return fmt.Errorf("ttl_seconds_after_finished must be >= 0, got %d", *ttl)
+	}
+	return nil
+}
+
+// ValidateMaxRetry validates the optional max_retry field of a BatchAPI job submission. nil means
+// retries are disabled; a negative count of additional attempts makes no sense.
+func ValidateMaxRetry(maxRetry *int) error {
+	if maxRetry == nil {
+		return nil
+	}
+	if *maxRetry < 0 {
+		return fmt.Errorf("max_retry must be >= 0, got %d", *maxRetry)
+	}
+	return nil
+}
+
+// ValidateEnqueueTimeout validates the optional enqueue_timeout field of a BatchAPI job
+// submission. nil disables the check (enqueuing is only bounded by the liveness check); a
+// non-positive timeout would terminate every job before it could enqueue a single batch.
+func ValidateEnqueueTimeout(enqueueTimeout *int64) error {
+	if enqueueTimeout == nil {
+		return nil
+	}
+	if *enqueueTimeout <= 0 {
+		return fmt.Errorf("enqueue_timeout must be > 0, got %d", *enqueueTimeout)
+	}
+	return nil
+}
+
+// ValidateMaxReceiveCount validates the max_receive_count field of a BatchAPI job's
+// dead_letter_queue. A batch must be allowed at least one receive before it can be considered
+// failed and moved off the main queue.
+func ValidateMaxReceiveCount(maxReceiveCount int) error {
+	if maxReceiveCount < 1 {
+		return fmt.Errorf("dead_letter_queue.max_receive_count must be >= 1, got %d", maxReceiveCount)
+	}
+	return nil
+}
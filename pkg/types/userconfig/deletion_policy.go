@@ -0,0 +1,46 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userconfig
+
+import "fmt"
+
+// DeletionPolicy controls what batchapi does with a job's k8s Job and queue once the job reaches
+// a terminal status, mirroring Kubernetes' own foreground/background/orphan cascading deletion.
+type DeletionPolicy string
+
+const (
+	// BackgroundDeletionPolicy deletes the queue and k8s Job immediately. This is the default.
+	BackgroundDeletionPolicy DeletionPolicy = "background"
+	// ForegroundDeletionPolicy deletes the queue immediately but waits for the k8s Job's pods to
+	// finish before deleting it, so downstream systems never observe a terminal status while
+	// workers are still writing final logs.
+	ForegroundDeletionPolicy DeletionPolicy = "foreground"
+	// OrphanDeletionPolicy deletes the queue but leaves the k8s Job in place for post-mortem debugging.
+	OrphanDeletionPolicy DeletionPolicy = "orphan"
+)
+
+// ValidateDeletionPolicy validates the optional deletion_policy field of a BatchAPI job
+// submission. An empty string means the field was unset and defaults to BackgroundDeletionPolicy.
+func ValidateDeletionPolicy(deletionPolicy DeletionPolicy) error {
+	switch deletionPolicy {
+	case "", BackgroundDeletionPolicy, ForegroundDeletionPolicy, OrphanDeletionPolicy:
+		return nil
+	default:
+		return fmt.Errorf("deletion_policy must be one of %s, %s, %s, got %s",
+			BackgroundDeletionPolicy, ForegroundDeletionPolicy, OrphanDeletionPolicy, deletionPolicy)
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 Cortex Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userconfig
+
+// Kind identifies which kind of API a resource belongs to.
+type Kind int
+
+const (
+	UnknownKind Kind = iota
+	RealtimeAPIKind
+	BatchAPIKind
+	TaskAPIKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case RealtimeAPIKind:
+		return "RealtimeAPI"
+	case BatchAPIKind:
+		return "BatchAPI"
+	case TaskAPIKind:
+		return "TaskAPI"
+	default:
+		return "unknown"
+	}
+}